@@ -0,0 +1,87 @@
+package libvirt
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMaybeTranspileButaneNotButane(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		variant string
+	}{
+		{"plain json", `{"ignition":{"version":"3.3.0"}}`, ""},
+		{"yaml without version", "variant: fcos\nstorage: {}\n", ""},
+		{"yaml without any variant", "version: 1.4.0\nstorage: {}\n", ""},
+		{"not yaml or json at all", "not even close to a document", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ign := &defIgnition{Variant: c.variant}
+			_, isButane, err := ign.maybeTranspileButane([]byte(c.content))
+			if isButane {
+				t.Fatalf("expected %q to not be detected as Butane", c.content)
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %s", err)
+			}
+		})
+	}
+}
+
+func TestMaybeTranspileButaneDetectsHeader(t *testing.T) {
+	content := "variant: fcos\nversion: 1.4.0\nstorage: {}\n"
+	ign := &defIgnition{ButaneBinary: "definitely-not-a-real-butane-binary"}
+	_, isButane, err := ign.maybeTranspileButane([]byte(content))
+	if !isButane {
+		t.Fatalf("expected content with a variant/version header to be detected as Butane")
+	}
+	if err == nil || !strings.Contains(err.Error(), "could not find") {
+		t.Fatalf("expected a 'could not find' error for a missing butane binary, got %v", err)
+	}
+}
+
+func TestMaybeTranspileButaneFallsBackToVariantAttribute(t *testing.T) {
+	content := "version: 1.4.0\nstorage: {}\n"
+	ign := &defIgnition{Variant: "fcos", ButaneBinary: "definitely-not-a-real-butane-binary"}
+	_, isButane, err := ign.maybeTranspileButane([]byte(content))
+	if !isButane {
+		t.Fatalf("expected content with only a version header plus a Variant attribute to be detected as Butane")
+	}
+	if err == nil {
+		t.Fatalf("expected an error because the configured butane binary doesn't exist")
+	}
+}
+
+func TestButaneDiagnosticRe(t *testing.T) {
+	cases := []struct {
+		line     string
+		severity string
+		lineNo   int
+		column   int
+		message  string
+	}{
+		{"error: line 3, column 5: unknown variant", "error", 3, 5, "unknown variant"},
+		{"warning: deprecated field \"foo\"", "warning", 0, 0, "deprecated field \"foo\""},
+		{"error: invalid config", "error", 0, 0, "invalid config"},
+	}
+	for _, c := range cases {
+		m := butaneDiagnosticRe.FindStringSubmatch(c.line)
+		if m == nil {
+			t.Fatalf("expected %q to match butaneDiagnosticRe", c.line)
+		}
+		if m[1] != c.severity {
+			t.Errorf("line %q: severity = %q, want %q", c.line, m[1], c.severity)
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		if lineNo != c.lineNo || column != c.column {
+			t.Errorf("line %q: line/column = %d/%d, want %d/%d", c.line, lineNo, column, c.lineNo, c.column)
+		}
+		if m[4] != c.message {
+			t.Errorf("line %q: message = %q, want %q", c.line, m[4], c.message)
+		}
+	}
+}
@@ -1,29 +1,138 @@
 package libvirt
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	libvirt "github.com/libvirt/libvirt-go"
 	"github.com/mitchellh/packer/common/uuid"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// defaultIgnitionHTTPTimeout/defaultIgnitionHTTPRetries are used when
+// HTTPTimeout/HTTPRetries are left negative. Both are set per resource
+// (http_timeout/http_retries); there is no provider-level setting.
+const (
+	defaultIgnitionHTTPTimeout = 30 * time.Second
+	defaultIgnitionHTTPRetries = 3
+
+	// ignitionMergeConfigVersion is the Ignition spec version used for the
+	// synthesized parent config emitted when merging remote Sources.
+	ignitionMergeConfigVersion = "3.3.0"
+	// ignitionMergeConfigVersionSHA256 is the minimum spec version that
+	// accepts a "sha256-" resource verification hash (3.3.0 only accepts
+	// "sha512-"); the parent config is bumped to this version whenever a
+	// merged source is verified with SHA256.
+	ignitionMergeConfigVersionSHA256 = "3.4.0"
 )
 
 type defIgnition struct {
 	Name     string
 	PoolName string
-	Content  string
+	// Content is kept as a back-compat shorthand: if set and Sources is
+	// empty, it populates a one-element Sources list.
+	Content string
+
+	// Sources mirrors Ignition's own config.merge/config.replace building
+	// blocks: each entry is inline JSON/Butane, a local file path, or an
+	// http(s):// URL, allowing a single resource to assemble a layered
+	// config out of several independently managed pieces.
+	Sources []ignitionSource
+	// InlineMerge, when true, fetches/reads all Sources and merges them
+	// client-side into a single flattened Ignition config instead of
+	// emitting a parent config whose ignition.config.merge references the
+	// sources for Ignition itself to fetch at boot.
+	InlineMerge bool
+
+	// Variant optionally sets the Butane "variant" for content that
+	// doesn't already declare one via a "variant:"/"version:" header.
+	Variant string
+	// Rendered holds the Ignition JSON produced by the most recent Butane
+	// transpile, so callers can expose it as a "rendered" computed
+	// attribute for users to inspect/diff.
+	Rendered string
+	// ButaneBinary is the butane (FCCT) binary used to transpile
+	// Butane/FCCT YAML into Ignition JSON. Defaults to "butane", resolved
+	// via $PATH.
+	ButaneBinary string
+	// FilesDir is passed to butane via --files-dir so relative local file
+	// references in the config (systemd units, storage files) are
+	// resolved against the Terraform module directory.
+	FilesDir string
+
+	// HTTPTimeout configures fetching of http(s):// Sources. A negative
+	// value (the default from newIgnitionDef) falls back to
+	// defaultIgnitionHTTPTimeout; zero is a valid explicit "no timeout",
+	// matching net/http.Client's own zero-value meaning.
+	HTTPTimeout time.Duration
+	// HTTPRetries configures fetching of http(s):// Sources. A negative
+	// value (the default from newIgnitionDef) falls back to
+	// defaultIgnitionHTTPRetries; zero is a valid explicit "don't retry".
+	HTTPRetries int
+}
+
+// ignitionSourceKind identifies how a single ignitionSource should be
+// resolved into bytes.
+type ignitionSourceKind int
+
+const (
+	ignitionSourceInline ignitionSourceKind = iota
+	ignitionSourceFile
+	ignitionSourceURL
+)
+
+// ignitionSource is a single entry of defIgnition.Sources.
+type ignitionSource struct {
+	// Content is inline JSON/Butane YAML, a local file path, or an
+	// http(s):// URL, depending on Kind.
+	Content string
+	Kind    ignitionSourceKind
+	// Headers carries optional authentication headers, only used when
+	// Kind == ignitionSourceURL.
+	Headers map[string]string
+	// SHA256/SHA512, when set, verify the fetched/read bytes before use.
+	SHA256 string
+	SHA512 string
+}
+
+// sourceLabel is a human-readable identifier for a source, for use in
+// error messages.
+func sourceLabel(src ignitionSource) string {
+	if src.Kind == ignitionSourceInline {
+		return "<inline>"
+	}
+	return src.Content
+}
+
+// resolvedIgnitionSource is an ignitionSource after its bytes have been
+// read (and, for remote sources, fetched and digest-verified).
+type resolvedIgnitionSource struct {
+	Data   []byte
+	Source ignitionSource
 }
 
 // Creates a new cloudinit with the defaults
 // the provider uses
 func newIgnitionDef() defIgnition {
-	ign := defIgnition{}
+	ign := defIgnition{
+		HTTPTimeout: -1,
+		HTTPRetries: -1,
+	}
 
 	return ign
 }
@@ -120,8 +229,8 @@ func getIgnitionVolumeKeyFromTerraformID(id string) (string, error) {
 	return s[0], nil
 }
 
-// Dumps the Ignition object - either generated by Terraform or supplied as a file -
-// to a temporary ignition file
+// Dumps the Ignition object - either generated by Terraform, supplied as a
+// file, or assembled from several Sources - to a temporary ignition file
 func (ign *defIgnition) createFile() (string, error) {
 	log.Print("Creating Ignition temporary file")
 	tempFile, err := ioutil.TempFile("", ign.Name)
@@ -131,34 +240,546 @@ func (ign *defIgnition) createFile() (string, error) {
 	}
 	defer tempFile.Close()
 
-	var file bool
-	file = true
-	if _, err := os.Stat(ign.Content); err != nil {
-		var js map[string]interface{}
-		if err_conf := json.Unmarshal([]byte(ign.Content), &js); err_conf != nil {
-			return "", fmt.Errorf("coreos_ignition 'content' is neither a file "+
-				"nor a valid json object %s", ign.Content)
+	resolved, err := ign.resolveSources()
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := ign.buildIgnitionPayload(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tempFile.Write(payload); err != nil {
+		return "", fmt.Errorf("Cannot write Ignition object to temporary " +
+			"ignition file")
+	}
+	return tempFile.Name(), nil
+}
+
+// normalizeSources applies the Content back-compat shorthand and classifies
+// every Sources entry as inline content, a local file, or a remote URL.
+func (ign *defIgnition) normalizeSources() {
+	if len(ign.Sources) == 0 && ign.Content != "" {
+		ign.Sources = []ignitionSource{{Content: ign.Content}}
+	}
+	for i := range ign.Sources {
+		src := &ign.Sources[i]
+		switch {
+		case strings.HasPrefix(src.Content, "http://"), strings.HasPrefix(src.Content, "https://"):
+			src.Kind = ignitionSourceURL
+		default:
+			if _, err := os.Stat(src.Content); err == nil {
+				src.Kind = ignitionSourceFile
+			} else {
+				src.Kind = ignitionSourceInline
+			}
 		}
-		file = false
 	}
+}
 
-	if !file {
-		if _, err := tempFile.WriteString(ign.Content); err != nil {
-			return "", fmt.Errorf("Cannot write Ignition object to temporary " +
-				"ignition file")
+// resolveSources reads/fetches every Source's raw bytes, verifying the
+// configured sha256/sha512 digest (if any) along the way.
+func (ign *defIgnition) resolveSources() ([]resolvedIgnitionSource, error) {
+	ign.normalizeSources()
+	if len(ign.Sources) == 0 {
+		return nil, fmt.Errorf("coreos_ignition: no 'content' or 'sources' supplied")
+	}
+
+	resolved := make([]resolvedIgnitionSource, 0, len(ign.Sources))
+	for _, src := range ign.Sources {
+		var data []byte
+		var err error
+		switch src.Kind {
+		case ignitionSourceURL:
+			data, err = ign.fetchRemoteSource(src)
+		case ignitionSourceFile:
+			data, err = ioutil.ReadFile(src.Content)
+			if err != nil {
+				err = fmt.Errorf("error opening supplied Ignition file %s: %s", src.Content, err)
+			}
+		default:
+			data = []byte(src.Content)
 		}
-	} else if file {
-		ignFile, err := os.Open(ign.Content)
 		if err != nil {
-			return "", fmt.Errorf("Error opening supplied Ignition file %s", ign.Content)
+			return nil, err
+		}
+
+		if err := verifyDigest(data, src.SHA256, src.SHA512); err != nil {
+			return nil, fmt.Errorf("error verifying Ignition source %s: %s", sourceLabel(src), err)
+		}
+
+		resolved = append(resolved, resolvedIgnitionSource{Data: data, Source: src})
+	}
+	return resolved, nil
+}
+
+// verifyDigest checks data against the given hex-encoded sha256/sha512
+// digests, when non-empty.
+func verifyDigest(data []byte, sha256Hex, sha512Hex string) error {
+	if sha256Hex != "" {
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), sha256Hex) {
+			return fmt.Errorf("sha256 mismatch: expected %s, got %x", sha256Hex, sum)
+		}
+	}
+	if sha512Hex != "" {
+		sum := sha512.Sum512(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), sha512Hex) {
+			return fmt.Errorf("sha512 mismatch: expected %s, got %x", sha512Hex, sum)
 		}
-		defer ignFile.Close()
-		_, err = io.Copy(tempFile, ignFile)
+	}
+	return nil
+}
+
+// fetchRemoteSource downloads an http(s):// source, retrying with a simple
+// exponential backoff up to ign.HTTPRetries times.
+func (ign *defIgnition) fetchRemoteSource(src ignitionSource) ([]byte, error) {
+	timeout := ign.HTTPTimeout
+	if timeout < 0 {
+		timeout = defaultIgnitionHTTPTimeout
+	}
+	retries := ign.HTTPRetries
+	if retries < 0 {
+		retries = defaultIgnitionHTTPRetries
+	}
+	client := &http.Client{Timeout: timeout}
+
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+			log.Printf("[DEBUG] retrying Ignition source %s in %s (attempt %d/%d): %s",
+				src.Content, backoff, attempt, retries, lastErr)
+			time.Sleep(backoff)
+		}
+
+		req, err := http.NewRequest("GET", src.Content, nil)
 		if err != nil {
-			return "", fmt.Errorf("Error copying supplied Igition file to temporary file: %s", ign.Content)
+			return nil, fmt.Errorf("error building request for Ignition source %s: %s", src.Content, err)
+		}
+		for k, v := range src.Headers {
+			req.Header.Set(k, v)
 		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, readErr := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		// 4xx means the request itself is wrong (bad auth, typo'd URL, ...)
+		// and retrying won't help; fail fast instead of burning through
+		// the backoff schedule. Only transport errors and 5xx are retried.
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return nil, fmt.Errorf("error fetching Ignition source %s: unexpected status %s", src.Content, resp.Status)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			lastErr = fmt.Errorf("unexpected status %s", resp.Status)
+			continue
+		}
+		if readErr != nil {
+			lastErr = readErr
+			continue
+		}
+		return body, nil
 	}
-	return tempFile.Name(), nil
+	return nil, fmt.Errorf("error fetching Ignition source %s after %d attempt(s): %s",
+		src.Content, retries+1, lastErr)
+}
+
+// decodeIgnitionContent normalizes a single source's raw bytes into valid
+// Ignition JSON: Ignition JSON is passed through unchanged, anything else is
+// treated as a Butane/FCCT YAML document and transpiled.
+func (ign *defIgnition) decodeIgnitionContent(content []byte) ([]byte, error) {
+	var js map[string]interface{}
+	if err := json.Unmarshal(content, &js); err == nil {
+		return content, nil
+	}
+
+	rendered, isButane, err := ign.maybeTranspileButane(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed Butane transpile: %s", err)
+	}
+	if !isButane {
+		return nil, fmt.Errorf("is neither valid Ignition JSON nor a valid Butane config")
+	}
+	return rendered, nil
+}
+
+// buildIgnitionPayload turns the resolved Sources into the final Ignition
+// JSON written to the temporary file, recording it on ign.Rendered so it can
+// be exposed as a "rendered" computed attribute for users to inspect/diff.
+// The payload is either a single flattened config (the single-source case,
+// or multiple sources with InlineMerge set) or a thin parent config whose
+// ignition.config.merge entries reference the remote sources for Ignition
+// to fetch and merge itself at boot.
+func (ign *defIgnition) buildIgnitionPayload(resolved []resolvedIgnitionSource) ([]byte, error) {
+	payload, err := ign.assembleIgnitionPayload(resolved)
+	if err != nil {
+		return nil, err
+	}
+	ign.Rendered = string(payload)
+	return payload, nil
+}
+
+// assembleIgnitionPayload does the actual assembly for buildIgnitionPayload.
+func (ign *defIgnition) assembleIgnitionPayload(resolved []resolvedIgnitionSource) ([]byte, error) {
+	if len(resolved) == 1 {
+		src := resolved[0]
+		// Back-compat: a single local file source is written through
+		// verbatim, exactly like the pre-Sources "content = <path>"
+		// behavior - it is not required to be (or sniffed as) Ignition
+		// JSON or Butane.
+		if src.Source.Kind == ignitionSourceFile {
+			return src.Data, nil
+		}
+		decoded, err := ign.decodeIgnitionContent(src.Data)
+		if err != nil {
+			return nil, fmt.Errorf("coreos_ignition source %s %s", sourceLabel(src.Source), err)
+		}
+		return decoded, nil
+	}
+
+	if ign.InlineMerge {
+		return ign.mergeIgnitionConfigs(resolved)
+	}
+
+	parentVersion := ignitionMergeConfigVersion
+	merges := make([]map[string]interface{}, 0, len(resolved))
+	for _, r := range resolved {
+		if r.Source.Kind != ignitionSourceURL {
+			return nil, fmt.Errorf("coreos_ignition: source %q must be fetched over http(s) to be merged "+
+				"remotely; set inline_merge = true to merge local/inline sources client-side", sourceLabel(r.Source))
+		}
+		merge := map[string]interface{}{"source": r.Source.Content}
+		if r.Source.SHA256 != "" {
+			merge["verification"] = map[string]interface{}{"hash": "sha256-" + r.Source.SHA256}
+			parentVersion = ignitionMergeConfigVersionSHA256
+		} else if r.Source.SHA512 != "" {
+			merge["verification"] = map[string]interface{}{"hash": "sha512-" + r.Source.SHA512}
+		}
+		if len(r.Source.Headers) > 0 {
+			merge["httpHeaders"] = sortedHTTPHeaders(r.Source.Headers)
+		}
+		merges = append(merges, merge)
+	}
+
+	parent := map[string]interface{}{
+		"ignition": map[string]interface{}{
+			"version": parentVersion,
+			"config": map[string]interface{}{
+				"merge": merges,
+			},
+		},
+	}
+	return json.Marshal(parent)
+}
+
+// sortedHTTPHeaders turns a header map into Ignition's
+// [{"name": ..., "value": ...}, ...] shape, sorted by name so the rendered
+// output (and the Computed "rendered" attribute derived from it) is stable
+// across runs instead of following Go's randomized map iteration order.
+func sortedHTTPHeaders(headers map[string]string) []map[string]string {
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	sorted := make([]map[string]string, 0, len(names))
+	for _, k := range names {
+		sorted = append(sorted, map[string]string{"name": k, "value": headers[k]})
+	}
+	return sorted
+}
+
+// mergeIgnitionConfigs decodes and flattens multiple Ignition documents into
+// one, approximating Ignition's own config.merge rules: objects are merged
+// key by key (later sources win on scalars), the well-known keyed arrays
+// (files, units, ...) have same-key entries replaced rather than duplicated
+// via mergeIgnitionArrayByKey, and the merged document's ignition.version is
+// the highest version reported by any source, per Ignition's own "merging
+// uses the greater of the two spec versions" rule.
+func (ign *defIgnition) mergeIgnitionConfigs(resolved []resolvedIgnitionSource) ([]byte, error) {
+	var merged map[string]interface{}
+	highestVersion := ""
+	for _, r := range resolved {
+		decoded, err := ign.decodeIgnitionContent(r.Data)
+		if err != nil {
+			return nil, fmt.Errorf("coreos_ignition source %s %s", sourceLabel(r.Source), err)
+		}
+
+		var doc map[string]interface{}
+		if err := json.Unmarshal(decoded, &doc); err != nil {
+			return nil, fmt.Errorf("error parsing Ignition source %s for inline merge: %s", sourceLabel(r.Source), err)
+		}
+
+		if v := ignitionDocVersion(doc); v != "" {
+			if highestVersion == "" || compareIgnitionVersions(v, highestVersion) > 0 {
+				highestVersion = v
+			}
+		}
+
+		if merged == nil {
+			merged = doc
+			continue
+		}
+		merged = mergeIgnitionMaps(merged, doc)
+	}
+
+	if highestVersion != "" {
+		ignitionSection, ok := merged["ignition"].(map[string]interface{})
+		if !ok {
+			ignitionSection = map[string]interface{}{}
+			merged["ignition"] = ignitionSection
+		}
+		ignitionSection["version"] = highestVersion
+	}
+
+	out, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing merged Ignition config: %s", err)
+	}
+	return out, nil
+}
+
+// ignitionDocVersion reads the ignition.version field reported by a decoded
+// Ignition document, or "" if it's missing/malformed.
+func ignitionDocVersion(doc map[string]interface{}) string {
+	ignitionSection, ok := doc["ignition"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	version, _ := ignitionSection["version"].(string)
+	return version
+}
+
+// compareIgnitionVersions compares two dotted Ignition spec versions (e.g.
+// "3.3.0" vs "3.4.0"), returning -1, 0, or 1. Non-numeric/missing components
+// compare as 0, so malformed versions degrade to "equal" rather than panicking.
+func compareIgnitionVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var an, bn int
+		if i < len(as) {
+			an, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			bn, _ = strconv.Atoi(bs[i])
+		}
+		if an != bn {
+			if an < bn {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// ignitionArrayMergeKeys maps the well-known Ignition array fields that hold
+// uniquely-keyed entries to the JSON field that identifies an entry, so a
+// later source's same-key entry replaces the earlier one instead of being
+// appended as a duplicate that Ignition would reject at boot. Arrays not listed here (e.g.
+// storage.luks, storage.disks.partitions) fall back to plain concatenation.
+var ignitionArrayMergeKeys = map[string]string{
+	"files":       "path",
+	"directories": "path",
+	"links":       "path",
+	"filesystems": "device",
+	"units":       "name",
+	"dropins":     "name",
+	"users":       "name",
+	"groups":      "name",
+}
+
+// mergeIgnitionMaps recursively merges overlay into base, returning base.
+func mergeIgnitionMaps(base, overlay map[string]interface{}) map[string]interface{} {
+	for k, v := range overlay {
+		if bv, ok := base[k]; ok {
+			switch bvTyped := bv.(type) {
+			case map[string]interface{}:
+				if ov, ok := v.(map[string]interface{}); ok {
+					base[k] = mergeIgnitionMaps(bvTyped, ov)
+					continue
+				}
+			case []interface{}:
+				if ov, ok := v.([]interface{}); ok {
+					if keyField, ok := ignitionArrayMergeKeys[k]; ok {
+						base[k] = mergeIgnitionArrayByKey(bvTyped, ov, keyField)
+					} else {
+						base[k] = append(append([]interface{}{}, bvTyped...), ov...)
+					}
+					continue
+				}
+			}
+		}
+		base[k] = v
+	}
+	return base
+}
+
+// mergeIgnitionArrayByKey merges two arrays of Ignition entries keyed by
+// keyField (e.g. "path" for storage.files, "name" for systemd.units):
+// an overlay entry whose keyField matches a base entry replaces it in
+// place, new keys are appended in overlay order. Entries missing keyField
+// (or with an empty string value) aren't uniquely identifiable, so they're
+// always appended rather than risking collisions on a shared zero value.
+func mergeIgnitionArrayByKey(base, overlay []interface{}, keyField string) []interface{} {
+	merged := append([]interface{}{}, base...)
+	index := make(map[string]int, len(merged))
+	for i, entry := range merged {
+		if key, ok := ignitionArrayEntryKey(entry, keyField); ok {
+			index[key] = i
+		}
+	}
+
+	for _, entry := range overlay {
+		key, ok := ignitionArrayEntryKey(entry, keyField)
+		if !ok {
+			merged = append(merged, entry)
+			continue
+		}
+		if i, exists := index[key]; exists {
+			merged[i] = entry
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, entry)
+	}
+	return merged
+}
+
+// ignitionArrayEntryKey extracts entry's keyField as a non-empty string,
+// returning ok=false when entry isn't an object or keyField is missing/empty.
+func ignitionArrayEntryKey(entry interface{}, keyField string) (key string, ok bool) {
+	m, ok := entry.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	key, ok = m[keyField].(string)
+	if !ok || key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+// butaneHeader is the subset of a Butane/FCCT document used to sniff
+// whether "content" is Ignition JSON or a Butane config that still needs
+// transpiling.
+type butaneHeader struct {
+	Variant string `yaml:"variant"`
+	Version string `yaml:"version"`
+}
+
+// maybeTranspileButane sniffs content for a Butane "variant:"/"version:"
+// header (falling back to the resource's Variant attribute when content
+// doesn't declare one) and, if found, transpiles it to Ignition JSON.
+// isButane is false when content doesn't look like a Butane config at all,
+// in which case err is always nil and the caller should fall back to its
+// own error handling.
+func (ign *defIgnition) maybeTranspileButane(content []byte) (rendered []byte, isButane bool, err error) {
+	var hdr butaneHeader
+	if yerr := yaml.Unmarshal(content, &hdr); yerr != nil {
+		return nil, false, nil
+	}
+	variant := hdr.Variant
+	if variant == "" {
+		variant = ign.Variant
+	}
+	if hdr.Version == "" || variant == "" {
+		return nil, false, nil
+	}
+
+	// content may be missing the "variant:" header itself, relying on the
+	// resource's Variant attribute instead; inject it so butane sees it.
+	if hdr.Variant == "" {
+		content = append([]byte(fmt.Sprintf("variant: %s\n", variant)), content...)
+	}
+
+	rendered, err = ign.transpileButane(content)
+	return rendered, true, err
+}
+
+// butaneDiagnostic is a single line/column-aware diagnostic reported by the
+// butane binary, surfaced through Terraform's error channel instead of a
+// single opaque message.
+type butaneDiagnostic struct {
+	Severity string
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (d butaneDiagnostic) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("%s: line %d, column %d: %s", d.Severity, d.Line, d.Column, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+}
+
+var butaneDiagnosticRe = regexp.MustCompile(`^(error|warning):\s*(?:line (\d+), column (\d+):\s*)?(.+)$`)
+
+// transpileButane shells out to the butane (FCCT) binary to turn a
+// Butane/FCCT YAML document into the equivalent Ignition JSON, passing
+// through --strict and --files-dir so local file references can be
+// embedded relative to the Terraform module directory.
+func (ign *defIgnition) transpileButane(input []byte) ([]byte, error) {
+	binary := ign.ButaneBinary
+	if binary == "" {
+		binary = "butane"
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, fmt.Errorf("could not find %q on $PATH: %s", binary, err)
+	}
+
+	args := []string{"--strict"}
+	if ign.FilesDir != "" {
+		args = append(args, "--files-dir", ign.FilesDir)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+
+	var diags []butaneDiagnostic
+	for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		if m := butaneDiagnosticRe.FindStringSubmatch(line); m != nil {
+			lineNo, _ := strconv.Atoi(m[2])
+			col, _ := strconv.Atoi(m[3])
+			diags = append(diags, butaneDiagnostic{Severity: m[1], Line: lineNo, Column: col, Message: m[4]})
+		} else {
+			diags = append(diags, butaneDiagnostic{Severity: "warning", Message: line})
+		}
+	}
+
+	if runErr != nil {
+		if len(diags) == 0 {
+			return nil, fmt.Errorf("butane: %s", runErr)
+		}
+		msgs := make([]string, len(diags))
+		for i, d := range diags {
+			msgs[i] = d.String()
+		}
+		return nil, fmt.Errorf("butane reported %d issue(s):\n%s", len(diags), strings.Join(msgs, "\n"))
+	}
+
+	for _, d := range diags {
+		log.Printf("[WARN] butane: %s", d.String())
+	}
+
+	return stdout.Bytes(), nil
 }
 
 // Creates a new defIgnition object from provided id
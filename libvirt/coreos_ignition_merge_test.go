@@ -0,0 +1,163 @@
+package libvirt
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVerifyDigest(t *testing.T) {
+	data := []byte("hello ignition")
+	sha256Sum := sha256.Sum256(data)
+	sha256Hex := hex.EncodeToString(sha256Sum[:])
+	sha512Sum := sha512.Sum512(data)
+	sha512Hex := hex.EncodeToString(sha512Sum[:])
+
+	if err := verifyDigest(data, "", ""); err != nil {
+		t.Errorf("no digests configured: expected no error, got %s", err)
+	}
+	if err := verifyDigest(data, "deadbeef", ""); err == nil {
+		t.Errorf("mismatched sha256: expected an error")
+	}
+	if err := verifyDigest(data, "", "deadbeef"); err == nil {
+		t.Errorf("mismatched sha512: expected an error")
+	}
+	if err := verifyDigest(data, sha256Hex, ""); err != nil {
+		t.Errorf("matching sha256: expected no error, got %s", err)
+	}
+	if err := verifyDigest(data, "", sha512Hex); err != nil {
+		t.Errorf("matching sha512: expected no error, got %s", err)
+	}
+	if err := verifyDigest(data, strings.ToUpper(sha256Hex), ""); err != nil {
+		t.Errorf("matching sha256 (uppercase): expected no error, got %s", err)
+	}
+}
+
+func TestMergeIgnitionMapsScalarOverride(t *testing.T) {
+	base := map[string]interface{}{"ignition": map[string]interface{}{"version": "3.3.0"}}
+	overlay := map[string]interface{}{"ignition": map[string]interface{}{"version": "3.4.0"}}
+
+	merged := mergeIgnitionMaps(base, overlay)
+
+	got := merged["ignition"].(map[string]interface{})["version"]
+	if got != "3.4.0" {
+		t.Errorf("version = %v, want later source to win (3.4.0)", got)
+	}
+}
+
+func TestMergeIgnitionMapsArrayConcatenation(t *testing.T) {
+	base := map[string]interface{}{
+		"storage": map[string]interface{}{
+			"luks": []interface{}{"a"},
+		},
+	}
+	overlay := map[string]interface{}{
+		"storage": map[string]interface{}{
+			"luks": []interface{}{"b", "c"},
+		},
+	}
+
+	merged := mergeIgnitionMaps(base, overlay)
+
+	luks := merged["storage"].(map[string]interface{})["luks"]
+	want := []interface{}{"a", "b", "c"}
+	if !reflect.DeepEqual(luks, want) {
+		t.Errorf("luks = %v, want %v", luks, want)
+	}
+}
+
+func TestMergeIgnitionMapsKeyedArrayReplacement(t *testing.T) {
+	base := map[string]interface{}{
+		"storage": map[string]interface{}{
+			"files": []interface{}{
+				map[string]interface{}{"path": "/etc/a", "contents": map[string]interface{}{"source": "base-a"}},
+				map[string]interface{}{"path": "/etc/b", "contents": map[string]interface{}{"source": "base-b"}},
+			},
+		},
+	}
+	overlay := map[string]interface{}{
+		"storage": map[string]interface{}{
+			"files": []interface{}{
+				map[string]interface{}{"path": "/etc/a", "contents": map[string]interface{}{"source": "overlay-a"}},
+				map[string]interface{}{"path": "/etc/c", "contents": map[string]interface{}{"source": "overlay-c"}},
+			},
+		},
+	}
+
+	merged := mergeIgnitionMaps(base, overlay)
+
+	files := merged["storage"].(map[string]interface{})["files"].([]interface{})
+	want := []interface{}{
+		map[string]interface{}{"path": "/etc/a", "contents": map[string]interface{}{"source": "overlay-a"}},
+		map[string]interface{}{"path": "/etc/b", "contents": map[string]interface{}{"source": "base-b"}},
+		map[string]interface{}{"path": "/etc/c", "contents": map[string]interface{}{"source": "overlay-c"}},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+}
+
+func TestMergeIgnitionMapsKeyedArrayAppendsUnkeyedEntries(t *testing.T) {
+	base := map[string]interface{}{
+		"storage": map[string]interface{}{
+			"files": []interface{}{
+				map[string]interface{}{"contents": map[string]interface{}{"source": "one"}},
+				map[string]interface{}{"contents": map[string]interface{}{"source": "two"}},
+			},
+		},
+	}
+	overlay := map[string]interface{}{
+		"storage": map[string]interface{}{
+			"files": []interface{}{
+				map[string]interface{}{"contents": map[string]interface{}{"source": "three"}},
+			},
+		},
+	}
+
+	merged := mergeIgnitionMaps(base, overlay)
+
+	files := merged["storage"].(map[string]interface{})["files"].([]interface{})
+	want := []interface{}{
+		map[string]interface{}{"contents": map[string]interface{}{"source": "one"}},
+		map[string]interface{}{"contents": map[string]interface{}{"source": "two"}},
+		map[string]interface{}{"contents": map[string]interface{}{"source": "three"}},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+}
+
+func TestCompareIgnitionVersions(t *testing.T) {
+	if v := compareIgnitionVersions("3.3.0", "3.4.0"); v >= 0 {
+		t.Errorf("compareIgnitionVersions(3.3.0, 3.4.0) = %d, want < 0", v)
+	}
+	if v := compareIgnitionVersions("3.4.0", "3.3.0"); v <= 0 {
+		t.Errorf("compareIgnitionVersions(3.4.0, 3.3.0) = %d, want > 0", v)
+	}
+	if v := compareIgnitionVersions("3.4.0", "3.4.0"); v != 0 {
+		t.Errorf("compareIgnitionVersions(3.4.0, 3.4.0) = %d, want 0", v)
+	}
+}
+
+func TestMergeIgnitionMapsNestedObjects(t *testing.T) {
+	base := map[string]interface{}{
+		"ignition": map[string]interface{}{"version": "3.3.0"},
+		"passwd":   map[string]interface{}{"users": []interface{}{"core"}},
+	}
+	overlay := map[string]interface{}{
+		"passwd": map[string]interface{}{"groups": []interface{}{"wheel"}},
+	}
+
+	merged := mergeIgnitionMaps(base, overlay)
+
+	passwd := merged["passwd"].(map[string]interface{})
+	if !reflect.DeepEqual(passwd["users"], []interface{}{"core"}) {
+		t.Errorf("users = %v, want untouched base value", passwd["users"])
+	}
+	if !reflect.DeepEqual(passwd["groups"], []interface{}{"wheel"}) {
+		t.Errorf("groups = %v, want overlay value to be added", passwd["groups"])
+	}
+}
@@ -0,0 +1,248 @@
+package libvirt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	libvirt "github.com/libvirt/libvirt-go"
+)
+
+// coreOSIgnitionClient pulls the shared libvirt connection and the per-pool
+// lock registry off the provider's meta value. Client (and the rest of
+// provider.go) is not part of this tree snapshot, so this is an assumption,
+// not a verified fact: it takes the *Client shape and helper/schema import
+// path (github.com/hashicorp/terraform/helper/schema, matching this repo's
+// pre-split SDK vintage - see the mitchellh/packer uuid dependency used
+// elsewhere in this package) that the rest of the provider's resources are
+// presumed to use. Before merging this resource, confirm both against the
+// real provider.go, and confirm resourceCoreOSIgnition isn't already wired
+// into Provider()'s ResourcesMap under a different name.
+func coreOSIgnitionClient(meta interface{}) (*libvirt.Connect, *LibVirtPoolSync, error) {
+	client, ok := meta.(*Client)
+	if !ok {
+		return nil, nil, fmt.Errorf("coreos_ignition: unexpected provider meta type %T", meta)
+	}
+	return client.libvirt, client.poolSync, nil
+}
+
+// resourceCoreOSIgnition defines the coreos_ignition resource: an Ignition
+// (or Butane/FCCT, transpiled on the fly) config uploaded to a libvirt
+// storage volume so it can be attached to a CoreOS/Flatcar domain.
+func resourceCoreOSIgnition() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceCoreOSIgnitionCreate,
+		Read:   resourceCoreOSIgnitionRead,
+		Delete: resourceCoreOSIgnitionDelete,
+		Exists: resourceCoreOSIgnitionExists,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"pool": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"content": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Description: "Shorthand for a one-element 'sources' list: inline Ignition JSON or " +
+					"Butane/FCCT YAML, or a path to a file that is uploaded as-is (a file is never " +
+					"parsed/transpiled, matching this attribute's pre-Butane behavior).",
+			},
+			// sources, inline_merge, http_timeout and http_retries below all
+			// funnel through coreOSIgnitionClient's meta -> *Client assumption
+			// above; re-check that assumption together with this schema
+			// before merge, since it's exercised on every apply that uses them.
+			"sources": {
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				Description: "Ignition JSON/Butane content, local file paths, or http(s):// URLs " +
+					"to merge/append, mirroring Ignition's own config.merge semantics. A single " +
+					"local file path source is uploaded as-is. With more than one source and " +
+					"inline_merge set, file sources are parsed/transpiled like any other source; " +
+					"without inline_merge, every source must be an http(s):// URL.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"content": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"sha256": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"sha512": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"headers": {
+							Type:     schema.TypeMap,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"inline_merge": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Description: "Merge 'sources' client-side into a single flattened Ignition config " +
+					"instead of emitting a parent config that references them for Ignition to " +
+					"fetch and merge itself at boot.",
+			},
+			"http_timeout": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     30,
+				Description: "Timeout, in seconds, for fetching http(s):// sources.",
+			},
+			"http_retries": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     3,
+				Description: "Number of retries (with exponential backoff) for fetching http(s):// sources.",
+			},
+			"variant": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Description: "Butane variant (e.g. fcos, openshift, flatcar) to assume " +
+					"when 'content' doesn't declare one via its own 'variant:' header.",
+			},
+			"butane_binary": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "butane",
+				Description: "butane (FCCT) binary used to transpile Butane content, resolved via $PATH.",
+			},
+			"files_dir": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Description: "Directory relative file references in 'content' (systemd units, " +
+					"storage files) are resolved against, passed to butane via --files-dir.",
+			},
+			"rendered": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Ignition JSON produced after transpiling/assembling 'content', for inspection/diffing.",
+			},
+		},
+	}
+}
+
+// expandIgnitionSources turns the "sources" list's raw Terraform
+// representation into the ignitionSource values defIgnition.Sources expects.
+func expandIgnitionSources(raw []interface{}) []ignitionSource {
+	sources := make([]ignitionSource, 0, len(raw))
+	for _, v := range raw {
+		m := v.(map[string]interface{})
+		src := ignitionSource{Content: m["content"].(string)}
+		if sha256, ok := m["sha256"].(string); ok {
+			src.SHA256 = sha256
+		}
+		if sha512, ok := m["sha512"].(string); ok {
+			src.SHA512 = sha512
+		}
+		if headers, ok := m["headers"].(map[string]interface{}); ok && len(headers) > 0 {
+			src.Headers = make(map[string]string, len(headers))
+			for k, v := range headers {
+				src.Headers[k] = v.(string)
+			}
+		}
+		sources = append(sources, src)
+	}
+	return sources
+}
+
+func resourceCoreOSIgnitionCreate(d *schema.ResourceData, meta interface{}) error {
+	virConn, poolSync, err := coreOSIgnitionClient(meta)
+	if err != nil {
+		return err
+	}
+
+	ign := newIgnitionDef()
+	ign.Name = d.Get("name").(string)
+	ign.PoolName = d.Get("pool").(string)
+	ign.Content = d.Get("content").(string)
+	ign.Sources = expandIgnitionSources(d.Get("sources").([]interface{}))
+	ign.InlineMerge = d.Get("inline_merge").(bool)
+	ign.Variant = d.Get("variant").(string)
+	ign.ButaneBinary = d.Get("butane_binary").(string)
+	ign.FilesDir = d.Get("files_dir").(string)
+	ign.HTTPTimeout = time.Duration(d.Get("http_timeout").(int)) * time.Second
+	ign.HTTPRetries = d.Get("http_retries").(int)
+
+	id, err := ign.CreateAndUpload(virConn, poolSync)
+	if err != nil {
+		return err
+	}
+	d.SetId(id)
+	d.Set("rendered", ign.Rendered)
+
+	return resourceCoreOSIgnitionRead(d, meta)
+}
+
+func resourceCoreOSIgnitionRead(d *schema.ResourceData, meta interface{}) error {
+	virConn, _, err := coreOSIgnitionClient(meta)
+	if err != nil {
+		return err
+	}
+
+	ign, err := newIgnitionDefFromRemoteVol(virConn, d.Id())
+	if err != nil {
+		return fmt.Errorf("error reading coreos_ignition %s: %s", d.Id(), err)
+	}
+
+	d.Set("name", ign.Name)
+	d.Set("pool", ign.PoolName)
+	return nil
+}
+
+func resourceCoreOSIgnitionDelete(d *schema.ResourceData, meta interface{}) error {
+	virConn, _, err := coreOSIgnitionClient(meta)
+	if err != nil {
+		return err
+	}
+
+	key, err := getIgnitionVolumeKeyFromTerraformID(d.Id())
+	if err != nil {
+		return err
+	}
+	volume, err := virConn.LookupStorageVolByKey(key)
+	if err != nil {
+		return fmt.Errorf("error retrieving volume for coreos_ignition %s: %s", d.Id(), err)
+	}
+	defer volume.Free()
+
+	return volume.Delete(0)
+}
+
+func resourceCoreOSIgnitionExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	virConn, _, err := coreOSIgnitionClient(meta)
+	if err != nil {
+		return false, err
+	}
+
+	key, err := getIgnitionVolumeKeyFromTerraformID(d.Id())
+	if err != nil {
+		return false, err
+	}
+	volume, err := virConn.LookupStorageVolByKey(key)
+	if err != nil {
+		return false, nil
+	}
+	defer volume.Free()
+	return true, nil
+}